@@ -0,0 +1,119 @@
+package rdmamap
+
+import (
+	"fmt"
+	"os"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+)
+
+// deviceCgroupType is the OCI/cgroup device type for all rdma char
+// devices: character devices, as opposed to "b" for block devices.
+const deviceCgroupType = "c"
+
+// CharDevice describes one character device node under /dev/infiniband,
+// resolved down to the major/minor pair and permissions a container
+// runtime needs to splice it into a container.
+type CharDevice struct {
+	Path     string
+	Major    int64
+	Minor    int64
+	FileMode os.FileMode
+	UID      uint32
+	GID      uint32
+}
+
+// GetRdmaCharDeviceInfos is the structured counterpart of
+// GetRdmaCharDevices: for every character device path it returns, it also
+// resolves the major/minor, mode, and ownership a container runtime needs
+// in order to create and authorize the node without having to stat it
+// itself. A path that no longer stats (e.g. racing device
+// creation/teardown) is skipped rather than failing the whole call, the
+// same "skip what isn't there" behavior GetRdmaCharDevices already uses.
+func GetRdmaCharDeviceInfos(rdmaDeviceName string) ([]CharDevice, error) {
+	paths := GetRdmaCharDevices(rdmaDeviceName)
+
+	devices := make([]CharDevice, 0, len(paths))
+	for _, path := range paths {
+		device, err := charDeviceFromPath(path)
+		if err != nil {
+			continue
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+func charDeviceFromPath(path string) (CharDevice, error) {
+	var stat unix.Stat_t
+	if err := unix.Stat(path, &stat); err != nil {
+		return CharDevice{}, fmt.Errorf("stat %v: %w", path, err)
+	}
+
+	return CharDevice{
+		Path:     path,
+		Major:    int64(unix.Major(uint64(stat.Rdev))), //nolint:unconvert
+		Minor:    int64(unix.Minor(uint64(stat.Rdev))), //nolint:unconvert
+		FileMode: os.FileMode(stat.Mode),
+		UID:      stat.Uid,
+		GID:      stat.Gid,
+	}, nil
+}
+
+// BuildOCIDevices resolves the character devices for rdmaDeviceName and
+// returns them as OCI runtime-spec LinuxDevice/LinuxDeviceCgroup objects,
+// ready to append to a container config's Linux.Devices and
+// Linux.Resources.Devices.
+func BuildOCIDevices(rdmaDeviceName string) ([]specs.LinuxDevice, []specs.LinuxDeviceCgroup, error) {
+	charDevices, err := GetRdmaCharDeviceInfos(rdmaDeviceName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	devices := make([]specs.LinuxDevice, 0, len(charDevices))
+	cgroupRules := make([]specs.LinuxDeviceCgroup, 0, len(charDevices))
+	for _, d := range charDevices {
+		uid := d.UID
+		gid := d.GID
+		major := d.Major
+		minor := d.Minor
+		fileMode := d.FileMode
+
+		devices = append(devices, specs.LinuxDevice{
+			Path:     d.Path,
+			Type:     deviceCgroupType,
+			Major:    major,
+			Minor:    minor,
+			FileMode: &fileMode,
+			UID:      &uid,
+			GID:      &gid,
+		})
+
+		cgroupRules = append(cgroupRules, specs.LinuxDeviceCgroup{
+			Allow:  true,
+			Type:   deviceCgroupType,
+			Major:  &major,
+			Minor:  &minor,
+			Access: "rwm",
+		})
+	}
+	return devices, cgroupRules, nil
+}
+
+// BuildCgroupDeviceRules resolves the character devices for
+// rdmaDeviceName and returns them as "c <major>:<minor> rwm" rule
+// strings, the format accepted by the cgroup v1 devices.allow file and by
+// the cgroup v2 eBPF device filter generators.
+func BuildCgroupDeviceRules(rdmaDeviceName string) ([]string, error) {
+	charDevices, err := GetRdmaCharDeviceInfos(rdmaDeviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]string, 0, len(charDevices))
+	for _, d := range charDevices {
+		rules = append(rules, fmt.Sprintf("%s %d:%d rwm", deviceCgroupType, d.Major, d.Minor))
+	}
+	return rules, nil
+}
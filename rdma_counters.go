@@ -0,0 +1,183 @@
+package rdmamap
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	counterXmitData      = "port_xmit_data"
+	counterRcvData       = "port_rcv_data"
+	counterXmitPkts      = "port_xmit_packets"
+	counterRcvPkts       = "port_rcv_packets"
+	counterSymbolError   = "symbol_error"
+	counterLinkDowned    = "link_downed"
+	counterPortRcvErrors = "port_rcv_errors"
+)
+
+// PortMetrics exposes the well-known IB port counters in typed form, on
+// top of the raw string-keyed maps returned by GetPortCounters and
+// GetPortHwCounters.
+type PortMetrics struct {
+	XmitData      uint64
+	RcvData       uint64
+	XmitPkts      uint64
+	RcvPkts       uint64
+	SymbolError   uint64
+	LinkDowned    uint64
+	PortRcvErrors uint64
+}
+
+func readCountersDir(dirName string) (map[string]uint64, error) {
+	fd, err := os.Open(dirName)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	fileInfos, err := fd.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := make(map[string]uint64)
+	for i := range fileInfos {
+		if fileInfos[i].IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dirName, fileInfos[i].Name()))
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			continue
+		}
+		counters[fileInfos[i].Name()] = value
+	}
+	return counters, nil
+}
+
+// GetPortCounters returns the contents of
+// /sys/class/infiniband/<rdmaDeviceName>/ports/<port>/counters as a map
+// keyed by counter name.
+func GetPortCounters(rdmaDeviceName, port string) (map[string]uint64, error) {
+	dirName := filepath.Join(RdmaClassDir, rdmaDeviceName, RdmaPortsdir, port, RdmaCountersDir)
+	return readCountersDir(dirName)
+}
+
+// GetPortHwCounters returns the contents of
+// /sys/class/infiniband/<rdmaDeviceName>/ports/<port>/hw_counters as a
+// map keyed by counter name.
+func GetPortHwCounters(rdmaDeviceName, port string) (map[string]uint64, error) {
+	dirName := filepath.Join(RdmaClassDir, rdmaDeviceName, RdmaPortsdir, port, RdmaHwCountersDir)
+	return readCountersDir(dirName)
+}
+
+// CollectPortMetrics reads the counters directory for rdmaDeviceName/port
+// and returns the subset of well-known IB counters callers usually want,
+// so monitoring code does not have to know the sysfs counter names.
+func CollectPortMetrics(rdmaDeviceName, port string) (PortMetrics, error) {
+	counters, err := GetPortCounters(rdmaDeviceName, port)
+	if err != nil {
+		return PortMetrics{}, err
+	}
+
+	return PortMetrics{
+		XmitData:      counters[counterXmitData],
+		RcvData:       counters[counterRcvData],
+		XmitPkts:      counters[counterXmitPkts],
+		RcvPkts:       counters[counterRcvPkts],
+		SymbolError:   counters[counterSymbolError],
+		LinkDowned:    counters[counterLinkDowned],
+		PortRcvErrors: counters[counterPortRcvErrors],
+	}, nil
+}
+
+// Sample is one Watch observation: the metrics for a single port of an
+// rdma device at time Time, and the delta since the previous sample for
+// that same port (zero on the first sample). Device holds
+// "<rdma device>/<port>".
+type Sample struct {
+	Time    time.Time
+	Device  string
+	Metrics PortMetrics
+	Delta   PortMetrics
+}
+
+func subtractMetrics(cur, prev PortMetrics) PortMetrics {
+	return PortMetrics{
+		XmitData:      cur.XmitData - prev.XmitData,
+		RcvData:       cur.RcvData - prev.RcvData,
+		XmitPkts:      cur.XmitPkts - prev.XmitPkts,
+		RcvPkts:       cur.RcvPkts - prev.RcvPkts,
+		SymbolError:   cur.SymbolError - prev.SymbolError,
+		LinkDowned:    cur.LinkDowned - prev.LinkDowned,
+		PortRcvErrors: cur.PortRcvErrors - prev.PortRcvErrors,
+	}
+}
+
+// Watch polls CollectPortMetrics for every port of every device in
+// devices every interval, and streams each observation, along with its
+// delta from the previous observation, on the returned channel. The
+// channel is closed when ctx is done. If devices is empty, all devices
+// returned by GetRdmaDeviceList are watched.
+func Watch(ctx context.Context, interval time.Duration, devices ...string) <-chan Sample {
+	out := make(chan Sample)
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		prev := make(map[string]PortMetrics)
+		seen := make(map[string]bool)
+
+		for {
+			watched := devices
+			if len(watched) == 0 {
+				watched = GetRdmaDeviceList()
+			}
+
+			for _, dev := range watched {
+				for _, port := range GetPorts(dev) {
+					metrics, err := CollectPortMetrics(dev, port)
+					if err != nil {
+						continue
+					}
+
+					key := dev + "/" + port
+					sample := Sample{
+						Time:    time.Now(),
+						Device:  key,
+						Metrics: metrics,
+					}
+					if seen[key] {
+						sample.Delta = subtractMetrics(metrics, prev[key])
+					}
+					prev[key] = metrics
+					seen[key] = true
+
+					select {
+					case out <- sample:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,21 @@
+package rdmamap
+
+import "testing"
+
+func TestSubtractMetrics(t *testing.T) {
+	prev := PortMetrics{XmitData: 100, RcvData: 50, XmitPkts: 10, SymbolError: 1}
+	cur := PortMetrics{XmitData: 150, RcvData: 80, XmitPkts: 12, SymbolError: 1}
+
+	got := subtractMetrics(cur, prev)
+	want := PortMetrics{XmitData: 50, RcvData: 30, XmitPkts: 2, SymbolError: 0}
+	if got != want {
+		t.Errorf("subtractMetrics() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSubtractMetricsZeroPrev(t *testing.T) {
+	cur := PortMetrics{XmitData: 5, RcvData: 5}
+	if got := subtractMetrics(cur, PortMetrics{}); got != cur {
+		t.Errorf("subtractMetrics(cur, zero) = %+v, want %+v", got, cur)
+	}
+}
@@ -0,0 +1,141 @@
+package rdmamap
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	RdmaGidTypesDir = "gid_attrs/types" //nolint:stylecheck,revive
+	RdmaGidsDir     = "gids"
+
+	// GID type strings as reported under gid_attrs/types/<i>. The kernel
+	// only ever reports these two (see gid_type_str in ib_core) — there
+	// is no separate "RoCE v1" string, IB and RoCE v1 share one.
+	GidTypeIB     = "IB/RoCE v1"
+	GidTypeRoCEv2 = "RoCE v2"
+)
+
+// GidEntry is one row of a port's GID table: the raw 16-byte GID, its
+// type, and the netdevice it is associated with, if any.
+type GidEntry struct {
+	Index  int
+	Gid    net.IP
+	Type   string
+	Netdev string
+}
+
+func readGidIndices(rdmaDeviceName, port string) ([]int, error) {
+	dirName := filepath.Join(RdmaClassDir, rdmaDeviceName, RdmaPortsdir, port, RdmaGidsDir)
+
+	fd, err := os.Open(dirName)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	fileInfos, err := fd.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]int, 0, len(fileInfos))
+	for i := range fileInfos {
+		index, err := strconv.Atoi(fileInfos[i].Name())
+		if err != nil {
+			continue
+		}
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+func readGidValue(rdmaDeviceName, port string, index int) (net.IP, error) {
+	fileName := filepath.Join(RdmaClassDir, rdmaDeviceName, RdmaPortsdir, port, RdmaGidsDir, strconv.Itoa(index))
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	gid := net.ParseIP(strings.TrimSpace(string(data)))
+	if gid == nil {
+		return nil, fmt.Errorf("invalid gid value in %v", fileName)
+	}
+	return gid, nil
+}
+
+func readGidType(rdmaDeviceName, port string, index int) string {
+	fileName := filepath.Join(RdmaClassDir, rdmaDeviceName, RdmaPortsdir, port, RdmaGidTypesDir, strconv.Itoa(index))
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readGidNetdev(rdmaDeviceName, port string, index int) string {
+	netdev, err := readNetdevName(rdmaDeviceName, port, strconv.Itoa(index))
+	if err != nil {
+		return ""
+	}
+	return netdev
+}
+
+// GetPortGids returns every entry of the GID table for rdmaDeviceName's
+// port, combining the raw gid value with its type and associated
+// netdevice. This is the first-class form of the traversal that
+// getNetdeviceIds/isNetdevForRdma already do internally for
+// getRdmaDeviceForEth.
+func GetPortGids(rdmaDeviceName, port string) ([]GidEntry, error) {
+	indices, err := readGidIndices(rdmaDeviceName, port)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]GidEntry, 0, len(indices))
+	for _, index := range indices {
+		gid, err := readGidValue(rdmaDeviceName, port, index)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, GidEntry{
+			Index:  index,
+			Gid:    gid,
+			Type:   readGidType(rdmaDeviceName, port, index),
+			Netdev: readGidNetdev(rdmaDeviceName, port, index),
+		})
+	}
+	return entries, nil
+}
+
+// FindGidIndex returns the sgid_index of the GID table entry for
+// rdmaDeviceName's port matching gidType and netdev, whose address equals
+// srcIP. Callers building RoCE QP attributes can use this instead of
+// shelling out to show_gids.
+func FindGidIndex(rdmaDeviceName, port, gidType, netdev string, srcIP net.IP) (int, error) {
+	entries, err := GetPortGids(rdmaDeviceName, port)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, entry := range entries {
+		if entry.Type != gidType {
+			continue
+		}
+		if entry.Netdev != netdev {
+			continue
+		}
+		if !entry.Gid.Equal(srcIP) {
+			continue
+		}
+		return entry.Index, nil
+	}
+	return -1, fmt.Errorf("no gid index found for %v/%v matching type %v, netdev %v, address %v",
+		rdmaDeviceName, port, gidType, netdev, srcIP)
+}
@@ -0,0 +1,435 @@
+package rdmamap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Netlink protocol family and RDMA_NLDEV subsystem/command numbers, as
+// defined in <linux/rdma_netlink.h>.
+const (
+	sysRdmaNetlink = 20 // NETLINK_RDMA
+
+	rdmaNlNldev = 5 // RDMA_NL_NLDEV
+
+	rdmaNldevCmdGet     = 1
+	rdmaNldevCmdSet     = 2
+	rdmaNldevCmdNewLink = 3
+	rdmaNldevCmdDelLink = 4
+	rdmaNldevCmdPortGet = 5
+
+	rdmaNldevAttrDevIndex     = 1
+	rdmaNldevAttrDevName      = 2
+	rdmaNldevAttrPortIndex    = 3
+	rdmaNldevAttrFwVersion    = 5
+	rdmaNldevAttrNodeGuid     = 6
+	rdmaNldevAttrSysImageGuid = 7
+	rdmaNldevAttrNdevName     = 51
+	rdmaNldevAttrLinkType     = 65
+
+	nlaAlignTo = 4
+)
+
+func nlaAlign(length int) int {
+	return (length + nlaAlignTo - 1) &^ (nlaAlignTo - 1)
+}
+
+// rdmaNlGetType builds the nlmsg_type field for a subsystem/command pair,
+// mirroring RDMA_NL_GET_TYPE() from the kernel header.
+func rdmaNlGetType(subsys, cmd uint16) uint16 {
+	return (subsys << 10) | cmd
+}
+
+// RdmaLinkPort describes the netdevice, if any, backing a single port of
+// an RdmaLink.
+type RdmaLinkPort struct {
+	PortIndex uint32
+	Netdev    string
+}
+
+// RdmaLink is the netlink equivalent of an entry under
+// /sys/class/infiniband, plus the per-port netdevice association that
+// sysfs cannot give us without an O(ports x gids) scan.
+type RdmaLink struct {
+	Index           uint32
+	Name            string
+	FirmwareVersion string
+	NodeGuid        string
+	SysImageGuid    string
+	Ports           []RdmaLinkPort
+}
+
+type nlAttr struct {
+	attrType uint16
+	value    []byte
+}
+
+func parseAttrs(data []byte) []nlAttr {
+	var attrs []nlAttr
+	for len(data) >= unix.SizeofNlAttr {
+		length := int(binary.LittleEndian.Uint16(data[0:2]))
+		attrType := binary.LittleEndian.Uint16(data[2:4]) &^ unix.NLA_F_NESTED &^ unix.NLA_F_NET_BYTEORDER
+		if length < unix.SizeofNlAttr || length > len(data) {
+			break
+		}
+		attrs = append(attrs, nlAttr{attrType: attrType, value: data[unix.SizeofNlAttr:length]})
+		data = data[nlaAlign(length):]
+	}
+	return attrs
+}
+
+func attrString(value []byte) string {
+	return strings.TrimRight(string(value), "\x00")
+}
+
+func attrUint32(value []byte) uint32 {
+	if len(value) < 4 {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(value)
+}
+
+// attrGUID formats an 8-byte little-endian node/sys-image GUID as
+// colon-separated hex octets, e.g. "11:22:33:44:55:66:77:88".
+func attrGUID(value []byte) string {
+	if len(value) < 8 {
+		return ""
+	}
+	return formatGUIDBytes(value[:8])
+}
+
+func formatGUIDBytes(guid []byte) string {
+	parts := make([]string, len(guid))
+	for i, b := range guid {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+func linkFromAttrs(attrs []nlAttr) *RdmaLink {
+	link := &RdmaLink{}
+	for _, a := range attrs {
+		switch a.attrType {
+		case rdmaNldevAttrDevIndex:
+			link.Index = attrUint32(a.value)
+		case rdmaNldevAttrDevName:
+			link.Name = attrString(a.value)
+		case rdmaNldevAttrFwVersion:
+			link.FirmwareVersion = attrString(a.value)
+		case rdmaNldevAttrNodeGuid:
+			link.NodeGuid = attrGUID(a.value)
+		case rdmaNldevAttrSysImageGuid:
+			link.SysImageGuid = attrGUID(a.value)
+		}
+	}
+	return link
+}
+
+type netlinkRequest struct {
+	header unix.NlMsghdr
+	data   []byte
+}
+
+func newNetlinkRequest(msgType uint16, dump bool) *netlinkRequest {
+	flags := uint16(unix.NLM_F_REQUEST | unix.NLM_F_ACK)
+	if dump {
+		flags |= unix.NLM_F_DUMP
+	}
+	return &netlinkRequest{
+		header: unix.NlMsghdr{
+			Type:  msgType,
+			Flags: flags,
+			Seq:   1,
+			Pid:   uint32(os.Getpid()),
+		},
+	}
+}
+
+func (r *netlinkRequest) addAttr(attrType uint16, value []byte) {
+	length := unix.SizeofNlAttr + len(value)
+	attr := make([]byte, nlaAlign(length))
+	binary.LittleEndian.PutUint16(attr[0:2], uint16(length))
+	binary.LittleEndian.PutUint16(attr[2:4], attrType)
+	copy(attr[unix.SizeofNlAttr:], value)
+	r.data = append(r.data, attr...)
+}
+
+func (r *netlinkRequest) bytes() []byte {
+	r.header.Len = uint32(unix.SizeofNlMsghdr + len(r.data))
+	buf := make([]byte, nlaAlign(int(r.header.Len)))
+	*(*unix.NlMsghdr)(unsafe.Pointer(&buf[0])) = r.header //nolint:gosec
+	copy(buf[unix.SizeofNlMsghdr:], r.data)
+	return buf
+}
+
+// rdmaNetlinkRequest sends a single RDMA_NL_NLDEV request over
+// NETLINK_RDMA and returns the payload of every non-control message in
+// the (possibly multi-part) reply.
+func rdmaNetlinkRequest(cmd uint16, dump bool, attrs []nlAttr) ([][]byte, error) {
+	sock, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW, sysRdmaNetlink)
+	if err != nil {
+		return nil, fmt.Errorf("rdma netlink socket: %w", err)
+	}
+	defer unix.Close(sock)
+
+	if err := unix.Bind(sock, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("rdma netlink bind: %w", err)
+	}
+
+	req := newNetlinkRequest(rdmaNlGetType(rdmaNlNldev, cmd), dump)
+	for _, a := range attrs {
+		req.addAttr(a.attrType, a.value)
+	}
+
+	if err := unix.Sendto(sock, req.bytes(), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("rdma netlink send: %w", err)
+	}
+
+	return receiveNetlinkMessages(sock)
+}
+
+func parseNetlinkMessages(data []byte) ([]unix.NlMsghdr, [][]byte) {
+	var headers []unix.NlMsghdr
+	var payloads [][]byte
+
+	for len(data) >= unix.SizeofNlMsghdr {
+		header := *(*unix.NlMsghdr)(unsafe.Pointer(&data[0])) //nolint:gosec
+		length := int(header.Len)
+		if length < unix.SizeofNlMsghdr || length > len(data) {
+			break
+		}
+		headers = append(headers, header)
+		payloads = append(payloads, data[unix.SizeofNlMsghdr:length])
+		data = data[nlaAlign(length):]
+	}
+	return headers, payloads
+}
+
+func receiveNetlinkMessages(sock int) ([][]byte, error) {
+	var payloads [][]byte
+	buf := make([]byte, 16384)
+
+	for {
+		n, _, err := unix.Recvfrom(sock, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("rdma netlink recv: %w", err)
+		}
+		headers, msgs := parseNetlinkMessages(buf[:n])
+
+		done := false
+		for i, header := range headers {
+			switch header.Type {
+			case unix.NLMSG_DONE:
+				done = true
+			case unix.NLMSG_ERROR:
+				if errno := int32(binary.LittleEndian.Uint32(msgs[i][0:4])); errno != 0 {
+					return nil, fmt.Errorf("rdma netlink error: %d", -errno)
+				}
+				done = true
+			default:
+				payloads = append(payloads, msgs[i])
+			}
+		}
+		if done {
+			break
+		}
+	}
+	return payloads, nil
+}
+
+// RdmaLinkList returns every RDMA link known to RDMA_NLDEV, with per-port
+// netdevice association already populated. It falls back to walking
+// /sys/class/infiniband when the RDMA netlink family is unavailable, e.g.
+// in containers without CAP_NET_ADMIN or on older kernels.
+func RdmaLinkList() ([]*RdmaLink, error) {
+	payloads, err := rdmaNetlinkRequest(rdmaNldevCmdGet, true, nil)
+	if err != nil {
+		return rdmaLinkListFromSysfs()
+	}
+
+	var links []*RdmaLink
+	for _, p := range payloads {
+		link := linkFromAttrs(parseAttrs(p))
+		if link.Name == "" {
+			continue
+		}
+		if ports, err := rdmaLinkPorts(link.Index, link.Name); err == nil {
+			link.Ports = ports
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+func rdmaLinkListFromSysfs() ([]*RdmaLink, error) {
+	var links []*RdmaLink
+	for _, name := range getRdmaDeviceListFromSysfs() {
+		link, err := rdmaLinkFromSysfs(name)
+		if err != nil {
+			continue
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+func rdmaLinkFromSysfs(rdmaDeviceName string) (*RdmaLink, error) {
+	guid, err := getNodeGUID(rdmaDeviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &RdmaLink{
+		Name:     rdmaDeviceName,
+		NodeGuid: formatGUIDBytes(guid),
+	}
+	for _, port := range GetPorts(rdmaDeviceName) {
+		link.Ports = append(link.Ports, portFromSysfs(rdmaDeviceName, port))
+	}
+	return link, nil
+}
+
+func portFromSysfs(rdmaDeviceName, port string) RdmaLinkPort {
+	var portIndex uint32
+	fmt.Sscanf(port, "%d", &portIndex) //nolint:errcheck
+
+	linkPort := RdmaLinkPort{PortIndex: portIndex}
+	for _, index := range getNetdeviceIds(rdmaDeviceName, port) {
+		name, err := readNetdevName(rdmaDeviceName, port, index)
+		if err != nil {
+			continue
+		}
+		linkPort.Netdev = name
+		break
+	}
+	return linkPort
+}
+
+func readNetdevName(rdmaDeviceName, port, index string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(RdmaClassDir, rdmaDeviceName, RdmaPortsdir, port,
+		RdmaGidAttrDir, RdmaGidAttrNdevDir, index))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// rdmaLinkPorts issues an RDMA_NLDEV_CMD_PORT_GET dump for devIndex and
+// returns the netdevice attached to each of its ports. This is the direct
+// lookup that replaces the getRdmaDeviceForEth sysfs scan.
+func rdmaLinkPorts(devIndex uint32, rdmaDeviceName string) ([]RdmaLinkPort, error) {
+	indexAttr := make([]byte, 4)
+	binary.LittleEndian.PutUint32(indexAttr, devIndex)
+
+	payloads, err := rdmaNetlinkRequest(rdmaNldevCmdPortGet, true,
+		[]nlAttr{{attrType: rdmaNldevAttrDevIndex, value: indexAttr}})
+	if err != nil {
+		var ports []RdmaLinkPort
+		for _, port := range GetPorts(rdmaDeviceName) {
+			ports = append(ports, portFromSysfs(rdmaDeviceName, port))
+		}
+		return ports, nil
+	}
+
+	var ports []RdmaLinkPort
+	for _, p := range payloads {
+		port := RdmaLinkPort{}
+		for _, a := range parseAttrs(p) {
+			switch a.attrType {
+			case rdmaNldevAttrPortIndex:
+				port.PortIndex = attrUint32(a.value)
+			case rdmaNldevAttrNdevName:
+				port.Netdev = attrString(a.value)
+			}
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// RdmaLinkByName returns the RdmaLink matching rdmaDeviceName.
+func RdmaLinkByName(rdmaDeviceName string) (*RdmaLink, error) {
+	links, err := RdmaLinkList()
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if link.Name == rdmaDeviceName {
+			return link, nil
+		}
+	}
+	return nil, fmt.Errorf("rdma link not found for %v", rdmaDeviceName)
+}
+
+// RdmaLinkByIndex returns the RdmaLink whose device index matches index.
+func RdmaLinkByIndex(index uint32) (*RdmaLink, error) {
+	links, err := RdmaLinkList()
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if link.Index == index {
+			return link, nil
+		}
+	}
+	return nil, fmt.Errorf("rdma link not found for index %v", index)
+}
+
+// RdmaLinkAdd creates a new soft RDMA link (e.g. of type "rxe" or "siw")
+// named name, stacked on top of the netdevice parent.
+func RdmaLinkAdd(name, linkType, parent string) error {
+	_, err := rdmaNetlinkRequest(rdmaNldevCmdNewLink, false, []nlAttr{
+		{attrType: rdmaNldevAttrDevName, value: nullTerminated(name)},
+		{attrType: rdmaNldevAttrLinkType, value: nullTerminated(linkType)},
+		{attrType: rdmaNldevAttrNdevName, value: nullTerminated(parent)},
+	})
+	return err
+}
+
+// RdmaLinkDel removes the soft RDMA link named name. The kernel resolves
+// the target device by index, not by name, so name is first looked up
+// via RdmaLinkByName.
+func RdmaLinkDel(name string) error {
+	link, err := RdmaLinkByName(name)
+	if err != nil {
+		return err
+	}
+
+	indexAttr := make([]byte, 4)
+	binary.LittleEndian.PutUint32(indexAttr, link.Index)
+
+	_, err = rdmaNetlinkRequest(rdmaNldevCmdDelLink, false, []nlAttr{
+		{attrType: rdmaNldevAttrDevIndex, value: indexAttr},
+	})
+	return err
+}
+
+// RdmaLinkSetName renames the RDMA link named oldName to newName. The
+// kernel resolves the target device by index, not by name, so oldName is
+// first looked up via RdmaLinkByName; newName is then sent as the single
+// DEV_NAME attribute.
+func RdmaLinkSetName(oldName, newName string) error {
+	link, err := RdmaLinkByName(oldName)
+	if err != nil {
+		return err
+	}
+
+	indexAttr := make([]byte, 4)
+	binary.LittleEndian.PutUint32(indexAttr, link.Index)
+
+	_, err = rdmaNetlinkRequest(rdmaNldevCmdSet, false, []nlAttr{
+		{attrType: rdmaNldevAttrDevIndex, value: indexAttr},
+		{attrType: rdmaNldevAttrDevName, value: nullTerminated(newName)},
+	})
+	return err
+}
+
+func nullTerminated(s string) []byte {
+	return append([]byte(s), 0)
+}
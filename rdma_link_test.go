@@ -0,0 +1,89 @@
+package rdmamap
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// buildAttr encodes a single netlink attribute (type + value, 4-byte
+// aligned), the same layout netlinkRequest.addAttr produces.
+func buildAttr(attrType uint16, value []byte) []byte {
+	length := unix.SizeofNlAttr + len(value)
+	buf := make([]byte, nlaAlign(length))
+	binary.LittleEndian.PutUint16(buf[0:2], uint16(length))
+	binary.LittleEndian.PutUint16(buf[2:4], attrType)
+	copy(buf[unix.SizeofNlAttr:], value)
+	return buf
+}
+
+func u32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func TestNlaAlign(t *testing.T) {
+	cases := map[int]int{0: 0, 1: 4, 4: 4, 5: 8, 8: 8, 9: 12}
+	for in, want := range cases {
+		if got := nlaAlign(in); got != want {
+			t.Errorf("nlaAlign(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestParseAttrs(t *testing.T) {
+	var data []byte
+	data = append(data, buildAttr(rdmaNldevAttrDevIndex, u32Bytes(7))...)
+	data = append(data, buildAttr(rdmaNldevAttrDevName, nullTerminated("mlx5_0"))...)
+
+	attrs := parseAttrs(data)
+	if len(attrs) != 2 {
+		t.Fatalf("parseAttrs returned %d attrs, want 2", len(attrs))
+	}
+	if attrs[0].attrType != rdmaNldevAttrDevIndex || attrUint32(attrs[0].value) != 7 {
+		t.Errorf("unexpected first attr: %+v", attrs[0])
+	}
+	if attrs[1].attrType != rdmaNldevAttrDevName || attrString(attrs[1].value) != "mlx5_0" {
+		t.Errorf("unexpected second attr: %+v", attrs[1])
+	}
+}
+
+func TestLinkFromAttrs(t *testing.T) {
+	guid := []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+	attrs := []nlAttr{
+		{attrType: rdmaNldevAttrDevIndex, value: u32Bytes(3)},
+		{attrType: rdmaNldevAttrDevName, value: nullTerminated("mlx5_1")},
+		{attrType: rdmaNldevAttrFwVersion, value: nullTerminated("16.35.1012")},
+		{attrType: rdmaNldevAttrNodeGuid, value: guid},
+	}
+
+	link := linkFromAttrs(attrs)
+	want := &RdmaLink{
+		Index:           3,
+		Name:            "mlx5_1",
+		FirmwareVersion: "16.35.1012",
+		NodeGuid:        "11:22:33:44:55:66:77:88",
+	}
+	if !reflect.DeepEqual(link, want) {
+		t.Errorf("linkFromAttrs() = %+v, want %+v", link, want)
+	}
+}
+
+func TestAttrGUID(t *testing.T) {
+	guid := []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00, 0x11}
+	if got, want := attrGUID(guid), "aa:bb:cc:dd:ee:ff:00:11"; got != want {
+		t.Errorf("attrGUID() = %q, want %q", got, want)
+	}
+	if got := attrGUID(guid[:4]); got != "" {
+		t.Errorf("attrGUID(short) = %q, want empty", got)
+	}
+}
+
+func TestRdmaNlGetType(t *testing.T) {
+	if got, want := rdmaNlGetType(rdmaNlNldev, rdmaNldevCmdGet), uint16(5<<10|1); got != want {
+		t.Errorf("rdmaNlGetType() = %d, want %d", got, want)
+	}
+}
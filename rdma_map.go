@@ -56,8 +56,21 @@ var (
 
 // GetRdmaDeviceList Returns a list of rdma device names
 //
-//nolint:prealloc
+// Tries the RDMA_NLDEV netlink path first, falling back to a plain
+// /sys/class/infiniband readdir when the netlink family is unavailable.
 func GetRdmaDeviceList() []string {
+	if links, err := RdmaLinkList(); err == nil {
+		devices := make([]string, 0, len(links))
+		for _, link := range links {
+			devices = append(devices, link.Name)
+		}
+		return devices
+	}
+	return getRdmaDeviceListFromSysfs()
+}
+
+//nolint:prealloc
+func getRdmaDeviceListFromSysfs() []string {
 	var rdmaDevices []string
 	fd, err := os.Open(RdmaClassDir)
 	if err != nil {
@@ -269,9 +282,22 @@ func isNetdevForRdma(rdmaDeviceName, port, index, netdevName string) bool {
 }
 
 func getRdmaDeviceForEth(netdevName string) (string, error) {
-	// Iterate over the list of rdma devices,
-	// read the gid table attribute netdev
-	// if the netdev matches, found the matching rdma device
+	// Try the netlink path first: RDMA_NLDEV_CMD_PORT_GET already gives us
+	// the netdev attached to each port, which is a direct lookup instead
+	// of the O(devices x ports x gids) sysfs scan below.
+	if links, err := RdmaLinkList(); err == nil {
+		for _, link := range links {
+			for _, port := range link.Ports {
+				if port.Netdev == netdevName {
+					return link.Name, nil
+				}
+			}
+		}
+	}
+
+	// Fall back to iterating over the list of rdma devices, reading the
+	// gid table attribute netdev; if the netdev matches, we found the
+	// matching rdma device.
 
 	devices := GetRdmaDeviceList()
 	for _, dev := range devices {
@@ -0,0 +1,181 @@
+package rdmamap
+
+import (
+	"encoding/binary"
+)
+
+// RDMA_NLDEV resource commands and attributes, as defined in
+// <linux/rdma_netlink.h>. Each RES_*_GET command dumps one netlink
+// message per resource instance, with the instance fields nested under a
+// single container attribute.
+const (
+	rdmaNldevCmdResGet     = 9
+	rdmaNldevCmdResQpGet   = 10
+	rdmaNldevCmdResCmIdGet = 11
+	rdmaNldevCmdResCqGet   = 12
+	rdmaNldevCmdResMrGet   = 13
+	rdmaNldevCmdResPdGet   = 14
+	rdmaNldevCmdResCtxGet  = 22
+
+	rdmaNldevAttrResQp   = 19 // nested
+	rdmaNldevAttrResCq   = 35 // nested
+	rdmaNldevAttrResMr   = 40 // nested
+	rdmaNldevAttrResPd   = 46 // nested
+	rdmaNldevAttrResCmId = 30 // nested
+	rdmaNldevAttrResCtx  = 86 // nested
+
+	rdmaNldevAttrResLqpn     = 21
+	rdmaNldevAttrResRkey     = 42
+	rdmaNldevAttrResLkey     = 43
+	rdmaNldevAttrResPid      = 28
+	rdmaNldevAttrResKernName = 29
+)
+
+// resourceKind identifies which RES_*_GET dump a resourceEntryFromPayload
+// call is decoding, so it knows which container attribute to descend
+// into and which Type to record. The same set of fields is decoded for
+// every kind.
+type resourceKind struct {
+	cmd       uint16
+	container uint16
+	typeName  string
+}
+
+var (
+	resourceKindQP   = resourceKind{cmd: rdmaNldevCmdResQpGet, container: rdmaNldevAttrResQp, typeName: "qp"}
+	resourceKindCQ   = resourceKind{cmd: rdmaNldevCmdResCqGet, container: rdmaNldevAttrResCq, typeName: "cq"}
+	resourceKindMR   = resourceKind{cmd: rdmaNldevCmdResMrGet, container: rdmaNldevAttrResMr, typeName: "mr"}
+	resourceKindPD   = resourceKind{cmd: rdmaNldevCmdResPdGet, container: rdmaNldevAttrResPd, typeName: "pd"}
+	resourceKindCMID = resourceKind{cmd: rdmaNldevCmdResCmIdGet, container: rdmaNldevAttrResCmId, typeName: "cm_id"}
+	resourceKindCtx  = resourceKind{cmd: rdmaNldevCmdResCtxGet, container: rdmaNldevAttrResCtx, typeName: "ctx"}
+
+	allResourceKinds = []resourceKind{
+		resourceKindQP, resourceKindCQ, resourceKindMR, resourceKindPD, resourceKindCMID, resourceKindCtx,
+	}
+)
+
+// ResourceEntry is a single live RDMA resource instance, as reported by
+// `rdma resource show`: who owns it and, for the resource types that have
+// one, its queue pair number or memory key.
+type ResourceEntry struct {
+	Type      string
+	PID       uint32
+	Comm      string
+	PortIndex uint32
+	LQPN      uint32
+	RKey      uint32
+	LKey      uint32
+}
+
+// ResourceSummary is the per-device resource count, the Go equivalent of
+// the "resource" column block of `rdma resource show`.
+type ResourceSummary struct {
+	QPCount   int
+	CQCount   int
+	MRCount   int
+	PDCount   int
+	CMIDCount int
+	CtxCount  int
+}
+
+func resourceEntryFromPayload(payload []byte, kind resourceKind) (ResourceEntry, bool) {
+	for _, a := range parseAttrs(payload) {
+		if a.attrType != kind.container {
+			continue
+		}
+
+		entry := ResourceEntry{Type: kind.typeName}
+		for _, f := range parseAttrs(a.value) {
+			switch f.attrType {
+			case rdmaNldevAttrPortIndex:
+				entry.PortIndex = attrUint32(f.value)
+			case rdmaNldevAttrResPid:
+				entry.PID = attrUint32(f.value)
+			case rdmaNldevAttrResKernName:
+				entry.Comm = attrString(f.value)
+			case rdmaNldevAttrResLqpn:
+				entry.LQPN = attrUint32(f.value)
+			case rdmaNldevAttrResRkey:
+				entry.RKey = attrUint32(f.value)
+			case rdmaNldevAttrResLkey:
+				entry.LKey = attrUint32(f.value)
+			}
+		}
+		return entry, true
+	}
+	return ResourceEntry{}, false
+}
+
+func resourceEntriesForKind(rdmaDeviceName string, kind resourceKind) ([]ResourceEntry, error) {
+	link, err := RdmaLinkByName(rdmaDeviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	indexAttr := make([]byte, 4)
+	binary.LittleEndian.PutUint32(indexAttr, link.Index)
+
+	payloads, err := rdmaNetlinkRequest(kind.cmd, true,
+		[]nlAttr{{attrType: rdmaNldevAttrDevIndex, value: indexAttr}})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ResourceEntry
+	for _, p := range payloads {
+		if entry, ok := resourceEntryFromPayload(p, kind); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// GetResourceDetails returns every live QP, CQ, MR, PD, CM_ID, and
+// context for rdmaDeviceName, decoded from the RDMA_NLDEV RES_*_GET
+// netlink dumps. This is the Go equivalent of `rdma resource show`
+// without exec'ing iproute2. A resource kind that the running
+// kernel/driver does not support (e.g. RES_CTX_GET on an older kernel)
+// is skipped rather than failing the whole call, so one unsupported dump
+// doesn't discard every other resource type already collected.
+func GetResourceDetails(rdmaDeviceName string) ([]ResourceEntry, error) {
+	var entries []ResourceEntry
+	for _, kind := range allResourceKinds {
+		kindEntries, err := resourceEntriesForKind(rdmaDeviceName, kind)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, kindEntries...)
+	}
+	return entries, nil
+}
+
+// GetResourceSummary returns the per-type resource counts for
+// rdmaDeviceName, equivalent to the counts shown by `rdma resource show`.
+func GetResourceSummary(rdmaDeviceName string) (ResourceSummary, error) {
+	entries, err := GetResourceDetails(rdmaDeviceName)
+	if err != nil {
+		return ResourceSummary{}, err
+	}
+	return summarizeResourceEntries(entries), nil
+}
+
+func summarizeResourceEntries(entries []ResourceEntry) ResourceSummary {
+	var summary ResourceSummary
+	for _, e := range entries {
+		switch e.Type {
+		case resourceKindQP.typeName:
+			summary.QPCount++
+		case resourceKindCQ.typeName:
+			summary.CQCount++
+		case resourceKindMR.typeName:
+			summary.MRCount++
+		case resourceKindPD.typeName:
+			summary.PDCount++
+		case resourceKindCMID.typeName:
+			summary.CMIDCount++
+		case resourceKindCtx.typeName:
+			summary.CtxCount++
+		}
+	}
+	return summary
+}
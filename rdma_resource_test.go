@@ -0,0 +1,48 @@
+package rdmamap
+
+import "testing"
+
+func TestResourceEntryFromPayload(t *testing.T) {
+	inner := append([]byte{}, buildAttr(rdmaNldevAttrResPid, u32Bytes(4242))...)
+	inner = append(inner, buildAttr(rdmaNldevAttrResKernName, nullTerminated("ib_write_bw"))...)
+	inner = append(inner, buildAttr(rdmaNldevAttrResLqpn, u32Bytes(17))...)
+	inner = append(inner, buildAttr(rdmaNldevAttrPortIndex, u32Bytes(1))...)
+
+	payload := buildAttr(rdmaNldevAttrResQp, inner)
+
+	entry, ok := resourceEntryFromPayload(payload, resourceKindQP)
+	if !ok {
+		t.Fatalf("resourceEntryFromPayload() returned ok=false")
+	}
+
+	want := ResourceEntry{
+		Type:      "qp",
+		PID:       4242,
+		Comm:      "ib_write_bw",
+		PortIndex: 1,
+		LQPN:      17,
+	}
+	if entry != want {
+		t.Errorf("resourceEntryFromPayload() = %+v, want %+v", entry, want)
+	}
+}
+
+func TestResourceEntryFromPayloadWrongContainer(t *testing.T) {
+	payload := buildAttr(rdmaNldevAttrResCq, buildAttr(rdmaNldevAttrResPid, u32Bytes(1)))
+
+	if _, ok := resourceEntryFromPayload(payload, resourceKindQP); ok {
+		t.Errorf("resourceEntryFromPayload() matched the wrong container attr")
+	}
+}
+
+func TestSummarizeResourceEntries(t *testing.T) {
+	entries := []ResourceEntry{
+		{Type: "qp"}, {Type: "qp"}, {Type: "cq"}, {Type: "mr"},
+		{Type: "pd"}, {Type: "cm_id"}, {Type: "ctx"},
+	}
+
+	want := ResourceSummary{QPCount: 2, CQCount: 1, MRCount: 1, PDCount: 1, CMIDCount: 1, CtxCount: 1}
+	if got := summarizeResourceEntries(entries); got != want {
+		t.Errorf("summarizeResourceEntries() = %+v, want %+v", got, want)
+	}
+}
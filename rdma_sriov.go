@@ -0,0 +1,125 @@
+package rdmamap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	RdmaDeviceNetDir = "device/net" //nolint:stylecheck,revive
+
+	switchdevDevlinkModeFile = "compat/devlink/mode"
+	switchdevModeName        = "switchdev"
+
+	physPortNameFile = "phys_port_name"
+
+	auxSubfunctionPrefix = "mlx5_core.sf."
+
+	sysClassNetDir = "/sys/class/net"
+)
+
+// deviceNetdevs returns the netdevices bound to rdmaDeviceName, i.e. the
+// entries of /sys/class/infiniband/<rdmaDeviceName>/device/net.
+func deviceNetdevs(rdmaDeviceName string) []string {
+	dirName := filepath.Join(RdmaClassDir, rdmaDeviceName, RdmaDeviceNetDir)
+	return getRdmaDevicesFromDir(dirName)
+}
+
+// GetRdmaDeviceForVF returns the rdma device name bound to virtual
+// function vfIndex of the physical function at PCI address pfPci, e.g.
+// pfPci "0000:05:00.0", vfIndex 2 resolves via the pfPci/virtfn2 symlink.
+func GetRdmaDeviceForVF(pfPci string, vfIndex int) (string, error) {
+	vfLink := filepath.Join(PciDevDir, pfPci, fmt.Sprintf("virtfn%d", vfIndex))
+	vfPath, err := filepath.EvalSymlinks(vfLink)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve vf %v of %v: %w", vfIndex, pfPci, err)
+	}
+
+	devices := GetRdmaDevicesForPcidev(filepath.Base(vfPath))
+	if len(devices) == 0 {
+		return "", fmt.Errorf("no rdma device found for vf %v of %v", vfIndex, pfPci)
+	}
+	return devices[0], nil
+}
+
+// GetPFRdmaDevice returns the rdma device name of the physical function
+// backing the virtual function at PCI address vfPci.
+func GetPFRdmaDevice(vfPci string) (string, error) {
+	pfLink := filepath.Join(PciDevDir, vfPci, "physfn")
+	pfPath, err := filepath.EvalSymlinks(pfLink)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve physfn of %v: %w", vfPci, err)
+	}
+
+	devices := GetRdmaDevicesForPcidev(filepath.Base(pfPath))
+	if len(devices) == 0 {
+		return "", fmt.Errorf("no rdma device found for physfn of %v", vfPci)
+	}
+	return devices[0], nil
+}
+
+// IsSwitchdevMode returns whether rdmaDeviceName's underlying netdevice
+// has eswitch mode set to switchdev, read from compat/devlink/mode.
+func IsSwitchdevMode(rdmaDeviceName string) (bool, error) {
+	netdevs := deviceNetdevs(rdmaDeviceName)
+	if len(netdevs) == 0 {
+		return false, fmt.Errorf("no netdevice found for %v", rdmaDeviceName)
+	}
+
+	modeFile := filepath.Join(sysClassNetDir, netdevs[0], switchdevDevlinkModeFile)
+	data, err := os.ReadFile(modeFile)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(data)) == switchdevModeName, nil
+}
+
+// GetVFRepresentorNetdev returns the VF representor netdevice bound to
+// rdmaDeviceName, identified by a phys_port_name of the form "pf0vf1".
+func GetVFRepresentorNetdev(rdmaDeviceName string) (string, error) {
+	for _, netdev := range deviceNetdevs(rdmaDeviceName) {
+		data, err := os.ReadFile(filepath.Join(sysClassNetDir, netdev, physPortNameFile))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(string(data))
+		if strings.HasPrefix(name, "pf") && strings.Contains(name, "vf") {
+			return netdev, nil
+		}
+	}
+	return "", fmt.Errorf("no vf representor netdevice found for %v", rdmaDeviceName)
+}
+
+// ListSubfunctions returns the auxiliary-bus subfunction device IDs
+// (e.g. "mlx5_core.sf.4") whose parent PCI device is pfPci.
+func ListSubfunctions(pfPci string) ([]string, error) {
+	fd, err := os.Open(AuxDevDir)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	fileInfos, err := fd.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	var subfunctions []string
+	for i := range fileInfos {
+		name := fileInfos[i].Name()
+		if !strings.HasPrefix(name, auxSubfunctionPrefix) {
+			continue
+		}
+
+		devPath, err := filepath.EvalSymlinks(filepath.Join(AuxDevDir, name))
+		if err != nil {
+			continue
+		}
+		if strings.Contains(devPath, pfPci) {
+			subfunctions = append(subfunctions, name)
+		}
+	}
+	return subfunctions, nil
+}